@@ -1,112 +1,114 @@
 /*
  * Tool to monitor a SolrCloud cluster collection.
  *
- * Perform a search and alert on the following metrics:
- * - number of docs
- * - document last_update date
- * - search result
- * - search time
- *
- * returns num docs + search time as perfdata
+ * Each concrete health check (search freshness, cluster status, mbeans
+ * metrics, ping) is a Checker (see checker.go). The command line flags
+ * below are a shorthand for running a single Checker; pass -config to
+ * instead run any number of named checks, concurrently, as described in
+ * a YAML file (see config.go), with their results aggregated into one
+ * Nagios result.
  */
 
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
-	"log"
-	"time"
+	"strings"
 
 	"github.com/olorin/nagiosplugin"
-	"github.com/rtt/Go-Solr"
 )
 
 var (
 	host     = flag.String("host", "localhost", "HTTP host of the SOLR service")
 	port     = flag.Int("port", 8080, "HTTP port of the SOLR service")
+	servers  = flag.String("servers", "", "Comma-separated list of http(s)://host:port Solr endpoints, tried with failover (overrides -host/-port)")
+	username = flag.String("username", "", "Username for HTTP Basic Auth")
+	password = flag.String("password", "", "Password for HTTP Basic Auth")
+	cafile   = flag.String("cafile", "", "Path to a PEM-encoded CA bundle used to verify the Solr server's certificate")
+	insecure = flag.Bool("insecure", false, "Skip TLS certificate verification")
 	core     = flag.String("core", "", "Solr core name")
 	query    = flag.String("query", "*:*", "Search query in the form key:value")
 	sortkey  = flag.String("sortkey", "", "Search result sort key (descending order) - should be a date field")
 	minhits  = flag.Int("minhits", 1000000, "Number of expected hits in the response")
 	maxqtime = flag.Int("maxqtime", 200, "Max query processing time (ms)")
+
+	mode = flag.String("mode", "search", "Check mode: \"search\" (default), \"clusterstatus\", \"mbeans\", \"ping\" or \"shardfreshness\"")
+
+	collection     = flag.String("collection", "", "Solr collection name (required for -mode=clusterstatus and -mode=shardfreshness)")
+	minreplicas    = flag.Int("minreplicas", 1, "Minimum number of live replicas required per shard (-mode=clusterstatus)")
+	maxrecovering  = flag.Int("maxrecovering", 0, "Maximum number of replicas allowed in recovering/down state across the collection (-mode=clusterstatus)")
+	expectedconfig = flag.String("expectedconfig", "", "If set, the collection's configName must match this value (-mode=clusterstatus)")
+
+	maxage = flag.Int("maxage", 1800, "Max age (seconds) of the newest document in the OLDEST shard before going CRITICAL (-mode=shardfreshness)")
+
+	warnPredicates predicateList
+	critPredicates predicateList
+
+	config = flag.String("config", "", "Path to a YAML file defining any number of named checks to run concurrently, instead of the flags above")
+
+	output   = flag.String("output", "nagios", "Result format: \"nagios\" (default, printed to stdout) or \"prometheus\" (written to -textfile)")
+	textfile = flag.String("textfile", "", "Path to atomically write node_exporter textfile collector output to (-output=prometheus)")
 )
 
-func main() {
+func init() {
+	flag.Var(&warnPredicates, "warn", "mbeans threshold predicate of the form metric<op>value, e.g. documentCache_hitratio<0.9; may be repeated (-mode=mbeans)")
+	flag.Var(&critPredicates, "crit", "mbeans threshold predicate of the form metric<op>value, e.g. select_avgTimePerRequest>500; may be repeated (-mode=mbeans)")
+}
 
-	// Initialize the check - this will return an UNKNOWN result
-	// until more results are added.
-	check := nagiosplugin.NewCheck()
-	// If we exit early or panic() we'll still output a result.
-	defer check.Finish()
+// predicateList lets -warn/-crit be repeated on the command line.
+type predicateList []string
 
-	// parse the cmd line args
-	flag.Parse()
+func (p *predicateList) String() string { return strings.Join(*p, ",") }
+func (p *predicateList) Set(v string) error {
+	*p = append(*p, v)
+	return nil
+}
 
-	// init the connection
-	s, err := solr.Init(*host, *port, *core)
+// checkerFromFlags builds the single Checker described by the legacy,
+// non--config flags, per -mode.
+func checkerFromFlags() (Checker, error) {
+	eps, err := endpoints()
 	if err != nil {
-		check.Unknownf("Invalid connection parameters")
-		log.Fatal(err)
+		return nil, err
 	}
 
-	// Build and perform the query
-	q := solr.Query{
-		Params: solr.URLParamMap{
-			"q": []string{*query},
-		},
-		Rows: 1,
-		Sort: fmt.Sprintf("%s+desc", *sortkey),
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := s.Select(&q)
-	if err != nil {
-		check.Unknownf("Unable to perform search query, check parameters and connection")
-		log.Fatal(err)
+	switch *mode {
+	case "clusterstatus":
+		return NewClusterStatusChecker("clusterstatus", client, eps, *username, *password, *collection, *minreplicas, *maxrecovering, *expectedconfig), nil
+	case "mbeans":
+		return NewMBeansChecker("mbeans", client, eps, *core, *username, *password, warnPredicates, critPredicates), nil
+	case "ping":
+		return NewPingChecker("ping", client, eps, *core, *username, *password), nil
+	case "shardfreshness":
+		return NewShardFreshnessChecker("shardfreshness", client, eps, *username, *password, *collection, *query, *sortkey, *maxage), nil
+	case "search":
+		return NewSearchFreshnessChecker("search", client, eps, *core, *username, *password, *query, *sortkey, *minhits, *maxqtime), nil
+	default:
+		return nil, nil
 	}
+}
 
-	// grab results for ease of use later on
-	results := res.Results
+func main() {
+	flag.Parse()
 
-	// process the results
-	if res.Status != 0 {
-		check.Criticalf("Search failed: Invalid Solr response status.")
-	}
-	if results.Len() == 0 {
-		check.Criticalf("Search returned zero documents.")
-	}
-	if results.NumFound < *minhits {
-		check.AddResult(nagiosplugin.WARNING, "Number of documents hits is lower than expected")
-	}
-	if res.QTime > *maxqtime {
-		check.AddPerfDatum("qtime", "ms", float64(res.QTime))
-		check.AddPerfDatum("documents", "c", float64(results.NumFound))
-		check.Criticalf("Response too slow: %d ms", res.QTime)
+	if *config != "" {
+		runConfig(*config)
+		return
 	}
 
-	// check the date of the document returned
-	// type-assert the result date field to a string
-	sortkeydate, ok := results.Get(0).Field(*sortkey).(string)
-	if ok {
-		lastupdate, err := time.Parse("2006-01-02T15:04:05Z", sortkeydate)
-		if err != nil {
-			check.Unknownf("Can't parse document's lastupdate field")
-			log.Fatal(err)
-		}
-		// check if last document's date is too old
-		if lastupdate.Before(time.Now().Add(-30 * time.Minute)) {
-			check.AddPerfDatum("qtime", "ms", float64(res.QTime))
-			check.AddPerfDatum("documents", "c", float64(results.NumFound))
-			check.Criticalf("Collection update issue: Last document is too old (%s)", lastupdate)
-		}
-
-	} else {
-		check.Unknownf("Cannot parse date field specified in sortkey")
-		log.Fatal("sortkey type error")
+	c, err := checkerFromFlags()
+	if err != nil {
+		nagiosplugin.Exit(nagiosplugin.UNKNOWN, "Invalid connection parameters: "+err.Error())
+	}
+	if c == nil {
+		nagiosplugin.Exit(nagiosplugin.UNKNOWN, "Unknown -mode "+*mode)
 	}
 
-	// print the result and exit
-	check.AddPerfDatum("qtime", "ms", float64(res.QTime))
-	check.AddPerfDatum("documents", "c", float64(results.NumFound))
-	check.AddResultf(nagiosplugin.OK, "Search processed in %dms, %d documents found", res.QTime, results.NumFound)
+	report([]checkOutcome{runChecker(context.Background(), c)})
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/olorin/nagiosplugin"
+)
+
+// PerfDatum is a single perfdata value produced by a Checker. It mirrors
+// nagiosplugin.PerfDatum, whose fields are unexported, so that a
+// multi-check run can prefix/merge values from several checkers before
+// handing them to one nagiosplugin.Check.
+type PerfDatum struct {
+	Label      string
+	Unit       string
+	Value      float64
+	Thresholds []float64
+}
+
+// Result is the outcome of a single Checker run: the worst-case Nagios
+// status, a human-readable summary of the result(s) at that status, and
+// any perfdata to report.
+type Result struct {
+	Status  nagiosplugin.Status
+	Message string
+
+	Perfdata []PerfDatum
+}
+
+// Checker is a single Solr health check. It can be run standalone (the
+// existing CLI flags are a shorthand for building one) or as part of a
+// YAML-configured, concurrently-executed multi-check run.
+type Checker interface {
+	// Name identifies this checker in multi-check output and perfdata
+	// labels.
+	Name() string
+
+	// Run executes the check. It must not call os.Exit, log.Fatal or
+	// otherwise terminate the process - that's the caller's job. A
+	// non-nil error means the check itself could not be completed
+	// (connection failure, malformed response, ...), as opposed to the
+	// check completing and finding a problem.
+	Run(ctx context.Context) (Result, error)
+}
+
+// resultBuilder accumulates sub-results the way nagiosplugin.Check does
+// internally (worst status wins; messages at that status are joined),
+// but exposes its state afterwards so a Checker can return a Result
+// instead of writing directly to a process-wide Check.
+type resultBuilder struct {
+	status   nagiosplugin.Status
+	messages map[nagiosplugin.Status][]string
+	perfdata []PerfDatum
+}
+
+func newResultBuilder() *resultBuilder {
+	return &resultBuilder{messages: make(map[nagiosplugin.Status][]string)}
+}
+
+// addResultf records a sub-result. If status is the highest yet seen,
+// it becomes the builder's overall status.
+func (r *resultBuilder) addResultf(status nagiosplugin.Status, format string, v ...interface{}) {
+	r.messages[status] = append(r.messages[status], fmt.Sprintf(format, v...))
+	if status > r.status {
+		r.status = status
+	}
+}
+
+func (r *resultBuilder) addPerfDatum(label, unit string, value float64, thresholds ...float64) {
+	r.perfdata = append(r.perfdata, PerfDatum{Label: label, Unit: unit, Value: value, Thresholds: thresholds})
+}
+
+func (r *resultBuilder) result() Result {
+	var message string
+	for i, m := range r.messages[r.status] {
+		if i > 0 {
+			message += ", "
+		}
+		message += m
+	}
+	return Result{Status: r.status, Message: message, Perfdata: r.perfdata}
+}
+
+// collectionNamer is implemented by Checkers that target a specific Solr
+// collection, so reporting can attach a "collection" label/tag.
+type collectionNamer interface {
+	Collection() string
+}
+
+// checkOutcome is one Checker's outcome, captured so it can be reported
+// either as Nagios plugin output or a Prometheus textfile.
+type checkOutcome struct {
+	name       string
+	collection string
+	result     Result
+	err        error
+}
+
+// runChecker runs c under ctx and captures its outcome.
+func runChecker(ctx context.Context, c Checker) checkOutcome {
+	result, err := c.Run(ctx)
+
+	var collection string
+	if cn, ok := c.(collectionNamer); ok {
+		collection = cn.Collection()
+	}
+
+	return checkOutcome{name: c.Name(), collection: collection, result: result, err: err}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/olorin/nagiosplugin"
+)
+
+// clusterStatusResponse is the subset of Solr's CLUSTERSTATUS response
+// (see https://solr.apache.org/guide/collections-api.html#clusterstatus)
+// this checker cares about.
+type clusterStatusResponse struct {
+	Cluster struct {
+		Collections map[string]struct {
+			ConfigName string `json:"configName"`
+			Shards     map[string]struct {
+				Replicas map[string]struct {
+					Core    string `json:"core"`
+					BaseURL string `json:"base_url"`
+					State   string `json:"state"`
+					Leader  string `json:"leader"`
+				} `json:"replicas"`
+			} `json:"shards"`
+		} `json:"collections"`
+	} `json:"cluster"`
+}
+
+// ClusterStatusChecker asserts per-shard leader/replica health for a
+// collection via Solr's CLUSTERSTATUS collections API.
+type ClusterStatusChecker struct {
+	name      string
+	client    *http.Client
+	endpoints []string
+
+	username, password string
+	collection         string
+	minReplicas        int
+	maxRecovering      int
+	expectedConfig     string
+}
+
+// NewClusterStatusChecker returns a ClusterStatusChecker for collection.
+// expectedConfig may be empty to skip the configName check.
+func NewClusterStatusChecker(name string, client *http.Client, endpoints []string, username, password, collection string, minReplicas, maxRecovering int, expectedConfig string) *ClusterStatusChecker {
+	return &ClusterStatusChecker{
+		name:           name,
+		client:         client,
+		endpoints:      endpoints,
+		username:       username,
+		password:       password,
+		collection:     collection,
+		minReplicas:    minReplicas,
+		maxRecovering:  maxRecovering,
+		expectedConfig: expectedConfig,
+	}
+}
+
+func (c *ClusterStatusChecker) Name() string { return c.name }
+
+// Collection implements collectionNamer.
+func (c *ClusterStatusChecker) Collection() string { return c.collection }
+
+func (c *ClusterStatusChecker) Run(ctx context.Context) (Result, error) {
+	rb := newResultBuilder()
+
+	path := fmt.Sprintf("/solr/admin/collections?action=CLUSTERSTATUS&collection=%s&wt=json", c.collection)
+
+	var status clusterStatusResponse
+	if err := getJSONWithFailover(ctx, c.client, c.endpoints, path, c.username, c.password, &status); err != nil {
+		return Result{}, fmt.Errorf("unable to fetch CLUSTERSTATUS: %w", err)
+	}
+
+	coll, ok := status.Cluster.Collections[c.collection]
+	if !ok {
+		return Result{}, fmt.Errorf("collection %q not found in CLUSTERSTATUS response", c.collection)
+	}
+
+	if c.expectedConfig != "" && coll.ConfigName != c.expectedConfig {
+		rb.addResultf(nagiosplugin.CRITICAL, "Collection %q uses configName %q, expected %q", c.collection, coll.ConfigName, c.expectedConfig)
+	}
+
+	var shards, activeReplicas, downReplicas, recoveringReplicas int
+	for shardName, shard := range coll.Shards {
+		shards++
+		hasLeader := false
+		liveReplicas := 0
+		for _, replica := range shard.Replicas {
+			switch replica.State {
+			case "active":
+				activeReplicas++
+				liveReplicas++
+			case "recovering":
+				recoveringReplicas++
+				liveReplicas++
+			case "down":
+				downReplicas++
+			}
+			if replica.Leader == "true" && replica.State == "active" {
+				hasLeader = true
+			}
+		}
+		if !hasLeader {
+			rb.addResultf(nagiosplugin.CRITICAL, "Shard %q has no live leader", shardName)
+		}
+		if liveReplicas < c.minReplicas {
+			rb.addResultf(nagiosplugin.CRITICAL, "Shard %q has %d live replicas, expected at least %d", shardName, liveReplicas, c.minReplicas)
+		}
+	}
+
+	if unhealthy := recoveringReplicas + downReplicas; unhealthy > c.maxRecovering {
+		rb.addResultf(nagiosplugin.CRITICAL, "%d replicas recovering/down, exceeds threshold of %d", unhealthy, c.maxRecovering)
+	}
+
+	rb.addPerfDatum("shards", "", float64(shards))
+	rb.addPerfDatum("active_replicas", "", float64(activeReplicas))
+	rb.addPerfDatum("down_replicas", "", float64(downReplicas))
+
+	rb.addResultf(nagiosplugin.OK, "Collection %q: %d shards, %d active replicas, %d down replicas", c.collection, shards, activeReplicas, downReplicas)
+
+	return rb.result(), nil
+}
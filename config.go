@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/olorin/nagiosplugin"
+	"gopkg.in/yaml.v3"
+)
+
+// checkConfig is one entry of a -config YAML file's "checks" list. Only
+// the fields relevant to Type are used.
+type checkConfig struct {
+	Name    string `yaml:"name"`
+	Type    string `yaml:"type"`
+	Servers string `yaml:"servers"`
+
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Timeout  string `yaml:"timeout"`
+
+	Core     string `yaml:"core"`
+	Query    string `yaml:"query"`
+	Sortkey  string `yaml:"sortkey"`
+	Minhits  int    `yaml:"minhits"`
+	Maxqtime int    `yaml:"maxqtime"`
+
+	Collection     string `yaml:"collection"`
+	Minreplicas    int    `yaml:"minreplicas"`
+	Maxrecovering  int    `yaml:"maxrecovering"`
+	Expectedconfig string `yaml:"expectedconfig"`
+	Maxage         int    `yaml:"maxage"`
+
+	Warn []string `yaml:"warn"`
+	Crit []string `yaml:"crit"`
+}
+
+// configFile is the top-level shape of a -config YAML file.
+type configFile struct {
+	Checks []checkConfig `yaml:"checks"`
+}
+
+// loadConfig reads and parses a -config YAML file.
+func loadConfig(path string) (*configFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg configFile
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Checks) == 0 {
+		return nil, fmt.Errorf("no checks defined")
+	}
+	return &cfg, nil
+}
+
+// namedChecker pairs a Checker with the per-check timeout to run it
+// under.
+type namedChecker struct {
+	checker Checker
+	timeout time.Duration
+}
+
+const defaultCheckTimeout = 10 * time.Second
+
+// buildChecker constructs the Checker described by cc, sharing client
+// across every check built from a config file.
+func buildChecker(cc checkConfig, client *http.Client) (namedChecker, error) {
+	if cc.Name == "" {
+		return namedChecker{}, fmt.Errorf("check is missing a name")
+	}
+
+	eps, err := parseEndpoints(cc.Servers)
+	if err != nil {
+		return namedChecker{}, fmt.Errorf("check %q: %w", cc.Name, err)
+	}
+
+	timeout := defaultCheckTimeout
+	if cc.Timeout != "" {
+		timeout, err = time.ParseDuration(cc.Timeout)
+		if err != nil {
+			return namedChecker{}, fmt.Errorf("check %q: invalid timeout: %w", cc.Name, err)
+		}
+	}
+
+	var checker Checker
+	switch cc.Type {
+	case "search":
+		checker = NewSearchFreshnessChecker(cc.Name, client, eps, cc.Core, cc.Username, cc.Password, cc.Query, cc.Sortkey, cc.Minhits, cc.Maxqtime)
+	case "clusterstatus":
+		checker = NewClusterStatusChecker(cc.Name, client, eps, cc.Username, cc.Password, cc.Collection, cc.Minreplicas, cc.Maxrecovering, cc.Expectedconfig)
+	case "mbeans":
+		checker = NewMBeansChecker(cc.Name, client, eps, cc.Core, cc.Username, cc.Password, cc.Warn, cc.Crit)
+	case "ping":
+		checker = NewPingChecker(cc.Name, client, eps, cc.Core, cc.Username, cc.Password)
+	case "shardfreshness":
+		maxage := cc.Maxage
+		if maxage == 0 {
+			maxage = 1800
+		}
+		checker = NewShardFreshnessChecker(cc.Name, client, eps, cc.Username, cc.Password, cc.Collection, cc.Query, cc.Sortkey, maxage)
+	default:
+		return namedChecker{}, fmt.Errorf("check %q: unknown type %q", cc.Name, cc.Type)
+	}
+
+	return namedChecker{checker: checker, timeout: timeout}, nil
+}
+
+// buildCheckers constructs every check in cfg, sharing client across all
+// of them.
+func buildCheckers(cfg *configFile, client *http.Client) ([]namedChecker, error) {
+	checkers := make([]namedChecker, 0, len(cfg.Checks))
+	for _, cc := range cfg.Checks {
+		nc, err := buildChecker(cc, client)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, nc)
+	}
+	return checkers, nil
+}
+
+// runCheckers runs every checker concurrently, each under its own
+// timeout, and returns their outcomes in the same order as checkers.
+func runCheckers(checkers []namedChecker) []checkOutcome {
+	outcomes := make([]checkOutcome, len(checkers))
+
+	var wg sync.WaitGroup
+	for i, nc := range checkers {
+		wg.Add(1)
+		go func(i int, nc namedChecker) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), nc.timeout)
+			defer cancel()
+
+			outcomes[i] = runChecker(ctx, nc.checker)
+		}(i, nc)
+	}
+	wg.Wait()
+
+	return outcomes
+}
+
+// runConfig implements -config: it loads path and runs every check
+// concurrently against a shared HTTP client, then reports their
+// outcomes per -output.
+func runConfig(path string) {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		nagiosplugin.Exit(nagiosplugin.UNKNOWN, fmt.Sprintf("Unable to load -config %q: %s", path, err))
+	}
+
+	client, err := newHTTPClient()
+	if err != nil {
+		nagiosplugin.Exit(nagiosplugin.UNKNOWN, "Invalid TLS configuration: "+err.Error())
+	}
+
+	checkers, err := buildCheckers(cfg, client)
+	if err != nil {
+		nagiosplugin.Exit(nagiosplugin.UNKNOWN, fmt.Sprintf("Invalid -config %q: %s", path, err))
+	}
+
+	report(runCheckers(checkers))
+}
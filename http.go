@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/rtt/Go-Solr"
+)
+
+// parseEndpoints splits a comma-separated list of http(s)://host:port
+// Solr base URLs, as accepted by -servers and the YAML config's
+// "servers" field.
+func parseEndpoints(servers string) ([]string, error) {
+	var eps []string
+	for _, s := range strings.Split(servers, ",") {
+		s = strings.TrimSpace(strings.TrimRight(s, "/"))
+		if s == "" {
+			continue
+		}
+		eps = append(eps, s)
+	}
+	if len(eps) == 0 {
+		return nil, fmt.Errorf("no usable endpoints in %q", servers)
+	}
+	return eps, nil
+}
+
+// endpoints returns the Solr base URLs (scheme://host:port) to try, per
+// the top-level -servers/-host/-port flags. If -servers is given it
+// takes precedence, allowing the check to be pointed at an LB or the
+// full list of ZK-registered nodes behind a SolrCloud collection.
+func endpoints() ([]string, error) {
+	if *servers == "" {
+		return []string{fmt.Sprintf("http://%s:%d", *host, *port)}, nil
+	}
+	return parseEndpoints(*servers)
+}
+
+// newHTTPClient builds the http.Client shared by every checker,
+// configured per -cafile/-insecure. It has no Timeout of its own: each
+// request is bounded by the context passed to selectFromEndpoint/getJSON,
+// so in -config mode a check's own timeout (or defaultCheckTimeout) is
+// what actually governs how long it may run. A single check built from
+// the command line flags runs with no deadline.
+func newHTTPClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: *insecure}
+
+	if *cafile != "" {
+		pem, err := ioutil.ReadFile(*cafile)
+		if err != nil {
+			return nil, fmt.Errorf("reading -cafile: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in -cafile %s", *cafile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}, nil
+}
+
+// checkHTTPStatus returns an error if resp did not succeed. Solr returns
+// a JSON body (with wt=json) even for 401/403/404/5xx responses, which
+// would otherwise silently decode into a zero-valued struct.
+func checkHTTPStatus(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	return nil
+}
+
+// selectFromEndpoint performs q against a single Solr base URL/core,
+// adding HTTP Basic Auth credentials when username is non-empty.
+func selectFromEndpoint(ctx context.Context, client *http.Client, endpoint, core, username, password string, q *solr.Query) (*solr.SelectResponse, error) {
+	conn := &solr.Connection{URL: fmt.Sprintf("%s/solr/%s", endpoint, core)}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", solr.SolrSelectString(conn, q.String(), "select"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkHTTPStatus(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return solr.SelectResponseFromHTTPResponse(body)
+}
+
+// selectWithFailover tries eps in a random order, returning the first
+// successful response. Each failed endpoint is logged and the next one
+// is tried.
+func selectWithFailover(ctx context.Context, client *http.Client, eps []string, core, username, password string, q *solr.Query) (*solr.SelectResponse, error) {
+	var lastErr error
+	for _, i := range rand.Perm(len(eps)) {
+		res, err := selectFromEndpoint(ctx, client, eps[i], core, username, password, q)
+		if err == nil {
+			return res, nil
+		}
+		log.Printf("solr endpoint %s failed: %s", eps[i], err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("all solr endpoints failed, last error: %s", lastErr)
+}
+
+// getJSON performs an authenticated GET against path (relative to a
+// Solr base URL, e.g. "/solr/admin/collections?...") and decodes the
+// response body as JSON into v.
+func getJSON(ctx context.Context, client *http.Client, endpoint, path, username, password string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+path, nil)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkHTTPStatus(resp); err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// getJSONWithFailover is getJSON, tried against eps in a random order
+// until one endpoint succeeds.
+func getJSONWithFailover(ctx context.Context, client *http.Client, eps []string, path, username, password string, v interface{}) error {
+	var lastErr error
+	for _, i := range rand.Perm(len(eps)) {
+		err := getJSON(ctx, client, eps[i], path, username, password, v)
+		if err == nil {
+			return nil
+		}
+		log.Printf("solr endpoint %s failed: %s", eps[i], err)
+		lastErr = err
+	}
+	return fmt.Errorf("all solr endpoints failed, last error: %s", lastErr)
+}
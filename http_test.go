@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEndpoints(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "http://solr1:8983", want: []string{"http://solr1:8983"}},
+		{in: "http://solr1:8983,http://solr2:8983", want: []string{"http://solr1:8983", "http://solr2:8983"}},
+		{in: " http://solr1:8983 , https://solr2:8983/", want: []string{"http://solr1:8983", "https://solr2:8983"}},
+		{in: "http://solr1:8983,,http://solr2:8983", want: []string{"http://solr1:8983", "http://solr2:8983"}},
+		{in: "", wantErr: true},
+		{in: " , ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEndpoints(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseEndpoints(%q): expected error, got %v", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseEndpoints(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseEndpoints(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/olorin/nagiosplugin"
+)
+
+// mbeansResponse is Solr's /admin/mbeans?stats=true response: solr-mbeans
+// is a flattened [category, entries, category, entries, ...] array
+// rather than a map, unless json.nl=map is requested.
+type mbeansResponse struct {
+	Mbeans []json.RawMessage `json:"solr-mbeans"`
+}
+
+type mbeanEntry struct {
+	Stats map[string]interface{} `json:"stats"`
+}
+
+// mbeansStats lists, per mbeans category, which handler/cache names to
+// pull stats from and which stat keys to turn into metrics.
+var mbeansStats = map[string]struct {
+	names []string
+	stats []string
+}{
+	"CACHE": {
+		names: []string{"documentCache", "filterCache", "queryResultCache"},
+		stats: []string{"hitratio", "evictions", "warmupTime"},
+	},
+	"QUERYHANDLER": {
+		names: []string{"/select"},
+		stats: []string{"avgRequestsPerSecond", "avgTimePerRequest", "5minRateReqsPerSecond", "errors"},
+	},
+	"UPDATEHANDLER": {
+		names: []string{"updateHandler"},
+		stats: []string{"autocommits", "cumulative_adds", "docsPending"},
+	},
+}
+
+// toFloat coerces an mbeans stat value (usually a JSON number, sometimes
+// a numeric string) to a float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// metricName turns an mbeans entry name into a perfdata/predicate-safe
+// label, e.g. "/select" -> "select".
+func metricName(name string) string {
+	return strings.Trim(name, "/")
+}
+
+// collectMBeansMetrics flattens resp into a "name_stat" -> value map per
+// mbeansStats.
+func collectMBeansMetrics(resp *mbeansResponse) map[string]float64 {
+	metrics := map[string]float64{}
+
+	for i := 0; i+1 < len(resp.Mbeans); i += 2 {
+		var category string
+		if err := json.Unmarshal(resp.Mbeans[i], &category); err != nil {
+			continue
+		}
+		wanted, ok := mbeansStats[category]
+		if !ok {
+			continue
+		}
+
+		var entries map[string]mbeanEntry
+		if err := json.Unmarshal(resp.Mbeans[i+1], &entries); err != nil {
+			continue
+		}
+
+		for _, name := range wanted.names {
+			entry, ok := entries[name]
+			if !ok {
+				continue
+			}
+			for _, stat := range wanted.stats {
+				if v, ok := toFloat(entry.Stats[stat]); ok {
+					metrics[metricName(name)+"_"+stat] = v
+				}
+			}
+		}
+	}
+
+	return metrics
+}
+
+// predicate is a parsed -warn/-crit threshold expression of the form
+// "metric<op>value", e.g. "documentCache_hitratio<0.9".
+type predicate struct {
+	metric string
+	op     string
+	value  float64
+}
+
+var predicateRe = regexp.MustCompile(`^([a-zA-Z0-9_/]+)(<=|>=|==|<|>)(-?[0-9.]+)$`)
+
+func parsePredicate(s string) (*predicate, error) {
+	m := predicateRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid predicate %q, expected form metric<op>value", s)
+	}
+	value, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return nil, err
+	}
+	return &predicate{metric: m[1], op: m[2], value: value}, nil
+}
+
+// matches reports whether metrics[p.metric] trips the predicate, and
+// whether the metric was found at all.
+func (p *predicate) matches(metrics map[string]float64) (tripped bool, known bool) {
+	v, ok := metrics[p.metric]
+	if !ok {
+		return false, false
+	}
+	switch p.op {
+	case "<":
+		return v < p.value, true
+	case ">":
+		return v > p.value, true
+	case "<=":
+		return v <= p.value, true
+	case ">=":
+		return v >= p.value, true
+	case "==":
+		return v == p.value, true
+	}
+	return false, true
+}
+
+// MBeansChecker collects cache/handler perfdata from Solr's mbeans API
+// and evaluates warn/crit threshold predicates against it.
+type MBeansChecker struct {
+	name      string
+	client    *http.Client
+	endpoints []string
+
+	core, username, password string
+	warn, crit               []string
+}
+
+// NewMBeansChecker returns an MBeansChecker for core. warn and crit are
+// predicates of the form "metric<op>value", e.g. "documentCache_hitratio<0.9".
+func NewMBeansChecker(name string, client *http.Client, endpoints []string, core, username, password string, warn, crit []string) *MBeansChecker {
+	return &MBeansChecker{
+		name:      name,
+		client:    client,
+		endpoints: endpoints,
+		core:      core,
+		username:  username,
+		password:  password,
+		warn:      warn,
+		crit:      crit,
+	}
+}
+
+func (c *MBeansChecker) Name() string { return c.name }
+
+func (c *MBeansChecker) Run(ctx context.Context) (Result, error) {
+	rb := newResultBuilder()
+
+	path := fmt.Sprintf("/solr/%s/admin/mbeans?stats=true&wt=json&cat=CORE&cat=QUERYHANDLER&cat=UPDATEHANDLER&cat=CACHE", c.core)
+
+	var resp mbeansResponse
+	if err := getJSONWithFailover(ctx, c.client, c.endpoints, path, c.username, c.password, &resp); err != nil {
+		return Result{}, fmt.Errorf("unable to fetch mbeans: %w", err)
+	}
+
+	metrics := collectMBeansMetrics(&resp)
+	for label, value := range metrics {
+		rb.addPerfDatum(label, "", value)
+	}
+
+	for _, raw := range c.crit {
+		applyPredicate(rb, nagiosplugin.CRITICAL, raw, metrics)
+	}
+	for _, raw := range c.warn {
+		applyPredicate(rb, nagiosplugin.WARNING, raw, metrics)
+	}
+
+	rb.addResultf(nagiosplugin.OK, "Collected %d mbeans metrics from core %q", len(metrics), c.core)
+
+	return rb.result(), nil
+}
+
+// applyPredicate parses and evaluates a single warn/crit predicate
+// against metrics, recording a result on rb if it trips (or if it's
+// malformed/unknown).
+func applyPredicate(rb *resultBuilder, status nagiosplugin.Status, raw string, metrics map[string]float64) {
+	p, err := parsePredicate(raw)
+	if err != nil {
+		rb.addResultf(nagiosplugin.UNKNOWN, "%s", err)
+		return
+	}
+	tripped, known := p.matches(metrics)
+	if !known {
+		rb.addResultf(nagiosplugin.UNKNOWN, "Unknown mbeans metric %q", p.metric)
+		return
+	}
+	if tripped {
+		rb.addResultf(status, "%s %s %v (actual %v)", p.metric, p.op, p.value, metrics[p.metric])
+	}
+}
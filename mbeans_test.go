@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParsePredicate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    predicate
+		wantErr bool
+	}{
+		{in: "documentCache_hitratio<0.9", want: predicate{metric: "documentCache_hitratio", op: "<", value: 0.9}},
+		{in: "select_avgTimePerRequest>500", want: predicate{metric: "select_avgTimePerRequest", op: ">", value: 500}},
+		{in: "updateHandler_docsPending>=100", want: predicate{metric: "updateHandler_docsPending", op: ">=", value: 100}},
+		{in: "foo==-1.5", want: predicate{metric: "foo", op: "==", value: -1.5}},
+		{in: "missingOperator", wantErr: true},
+		{in: "foo<bar", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePredicate(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parsePredicate(%q): expected error, got %+v", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePredicate(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("parsePredicate(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+}
+
+func TestCollectMBeansMetrics(t *testing.T) {
+	raw := `[
+		"CACHE", {
+			"documentCache": {"stats": {"hitratio": 0.95, "evictions": 3}},
+			"filterCache": {"stats": {"hitratio": 0.5}}
+		},
+		"QUERYHANDLER", {
+			"/select": {"stats": {"avgTimePerRequest": 12.5, "errors": 0}}
+		},
+		"UPDATEHANDLER", {
+			"updateHandler": {"stats": {"autocommits": 4, "docsPending": "7"}}
+		},
+		"IGNOREDCATEGORY", {
+			"whatever": {"stats": {"hitratio": 1}}
+		}
+	]`
+
+	var mbeans []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &mbeans); err != nil {
+		t.Fatalf("unmarshal fixture: %s", err)
+	}
+	resp := &mbeansResponse{Mbeans: mbeans}
+
+	metrics := collectMBeansMetrics(resp)
+
+	want := map[string]float64{
+		"documentCache_hitratio":    0.95,
+		"documentCache_evictions":   3,
+		"filterCache_hitratio":      0.5,
+		"select_avgTimePerRequest":  12.5,
+		"select_errors":             0,
+		"updateHandler_autocommits": 4,
+		"updateHandler_docsPending": 7,
+	}
+
+	for k, v := range want {
+		got, ok := metrics[k]
+		if !ok {
+			t.Errorf("collectMBeansMetrics: missing metric %q", k)
+			continue
+		}
+		if got != v {
+			t.Errorf("collectMBeansMetrics: metric %q = %v, want %v", k, got, v)
+		}
+	}
+	if len(metrics) != len(want) {
+		t.Errorf("collectMBeansMetrics: got %d metrics, want %d (metrics: %v)", len(metrics), len(want), metrics)
+	}
+}
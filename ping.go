@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/olorin/nagiosplugin"
+)
+
+// pingResponse is Solr's /admin/ping response.
+type pingResponse struct {
+	Status         string `json:"status"`
+	ResponseHeader struct {
+		QTime int `json:"QTime"`
+	} `json:"responseHeader"`
+}
+
+// PingChecker is a minimal liveness check against a core's ping handler.
+type PingChecker struct {
+	name      string
+	client    *http.Client
+	endpoints []string
+
+	core, username, password string
+}
+
+// NewPingChecker returns a PingChecker for core.
+func NewPingChecker(name string, client *http.Client, endpoints []string, core, username, password string) *PingChecker {
+	return &PingChecker{name: name, client: client, endpoints: endpoints, core: core, username: username, password: password}
+}
+
+func (c *PingChecker) Name() string { return c.name }
+
+func (c *PingChecker) Run(ctx context.Context) (Result, error) {
+	rb := newResultBuilder()
+
+	path := fmt.Sprintf("/solr/%s/admin/ping?wt=json", c.core)
+
+	var resp pingResponse
+	if err := getJSONWithFailover(ctx, c.client, c.endpoints, path, c.username, c.password, &resp); err != nil {
+		return Result{}, fmt.Errorf("ping failed: %w", err)
+	}
+
+	rb.addPerfDatum("qtime", "ms", float64(resp.ResponseHeader.QTime))
+	if resp.Status != "OK" {
+		rb.addResultf(nagiosplugin.CRITICAL, "Ping returned status %q", resp.Status)
+	} else {
+		rb.addResultf(nagiosplugin.OK, "Ping OK (%dms)", resp.ResponseHeader.QTime)
+	}
+
+	return rb.result(), nil
+}
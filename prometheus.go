@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olorin/nagiosplugin"
+)
+
+// writePrometheusTextfile renders outcomes in node_exporter textfile
+// collector format and atomically replaces path with the result, so a
+// concurrently-running node_exporter never reads a partial file.
+func writePrometheusTextfile(path string, outcomes []checkOutcome) error {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, "# HELP solr_check_up Whether the check reached Solr and parsed its response (1) or not (0).")
+	fmt.Fprintln(&b, "# TYPE solr_check_up gauge")
+	fmt.Fprintln(&b, "# HELP solr_check_status Nagios-style check status (0=OK, 1=WARNING, 2=CRITICAL, 3=UNKNOWN).")
+	fmt.Fprintln(&b, "# TYPE solr_check_status gauge")
+	fmt.Fprintln(&b, "# HELP solr_check_qtime_ms Solr query time in milliseconds, where reported by the check.")
+	fmt.Fprintln(&b, "# TYPE solr_check_qtime_ms gauge")
+	fmt.Fprintln(&b, "# HELP solr_check_numfound Number of documents matched, where reported by the check.")
+	fmt.Fprintln(&b, "# TYPE solr_check_numfound gauge")
+	fmt.Fprintln(&b, "# HELP solr_check_last_update_age_seconds Age of the newest matching document, where reported by the check.")
+	fmt.Fprintln(&b, "# TYPE solr_check_last_update_age_seconds gauge")
+
+	for _, o := range outcomes {
+		labels := fmt.Sprintf(`check=%q,collection=%q`, o.name, o.collection)
+
+		up := 1
+		if o.err != nil {
+			up = 0
+		}
+		fmt.Fprintf(&b, "solr_check_up{%s} %d\n", labels, up)
+		if o.err != nil {
+			continue
+		}
+
+		fmt.Fprintf(&b, "solr_check_status{%s} %d\n", labels, o.result.Status)
+
+		for _, pd := range o.result.Perfdata {
+			switch pd.Label {
+			case "qtime":
+				fmt.Fprintf(&b, "solr_check_qtime_ms{%s} %v\n", labels, pd.Value)
+			case "documents":
+				fmt.Fprintf(&b, "solr_check_numfound{%s} %v\n", labels, pd.Value)
+			case "age":
+				fmt.Fprintf(&b, "solr_check_last_update_age_seconds{%s} %v\n", labels, pd.Value)
+			}
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".solr_check_*.prom.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Rename is atomic on the same filesystem, so node_exporter's
+	// textfile collector never observes a partially-written file.
+	return os.Rename(tmp.Name(), path)
+}
+
+// report renders outcomes per -output: either a single aggregated
+// Nagios result (worst status wins, perfdata merged and prefixed with
+// each check's name when there's more than one) or a Prometheus
+// textfile.
+func report(outcomes []checkOutcome) {
+	if *output == "prometheus" {
+		if *textfile == "" {
+			nagiosplugin.Exit(nagiosplugin.UNKNOWN, "-output=prometheus requires -textfile")
+		}
+		if err := writePrometheusTextfile(*textfile, outcomes); err != nil {
+			nagiosplugin.Exit(nagiosplugin.UNKNOWN, "writing -textfile: "+err.Error())
+		}
+		return
+	}
+
+	check := nagiosplugin.NewCheck()
+	defer check.Finish()
+
+	prefixed := len(outcomes) > 1
+	for _, o := range outcomes {
+		if o.err != nil {
+			check.AddResultf(nagiosplugin.UNKNOWN, "%s: %s", o.name, o.err)
+			continue
+		}
+
+		label := o.name + "_"
+		message := o.result.Message
+		if prefixed && message != "" {
+			message = o.name + ": " + message
+		}
+		if message != "" {
+			check.AddResult(o.result.Status, message)
+		}
+		for _, pd := range o.result.Perfdata {
+			if prefixed {
+				check.AddPerfDatum(label+pd.Label, pd.Unit, pd.Value, pd.Thresholds...)
+			} else {
+				check.AddPerfDatum(pd.Label, pd.Unit, pd.Value, pd.Thresholds...)
+			}
+		}
+	}
+}
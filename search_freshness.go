@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/olorin/nagiosplugin"
+	"github.com/rtt/Go-Solr"
+)
+
+// SearchFreshnessChecker is the original check: it runs a search query
+// sorted by a date field and alerts on hit count, query time and the
+// age of the newest document.
+type SearchFreshnessChecker struct {
+	name      string
+	client    *http.Client
+	endpoints []string
+
+	core, username, password string
+	query, sortkey           string
+	minHits, maxQTime        int
+}
+
+// NewSearchFreshnessChecker returns a SearchFreshnessChecker for core,
+// querying against endpoints via client.
+func NewSearchFreshnessChecker(name string, client *http.Client, endpoints []string, core, username, password, query, sortkey string, minHits, maxQTime int) *SearchFreshnessChecker {
+	return &SearchFreshnessChecker{
+		name:      name,
+		client:    client,
+		endpoints: endpoints,
+		core:      core,
+		username:  username,
+		password:  password,
+		query:     query,
+		sortkey:   sortkey,
+		minHits:   minHits,
+		maxQTime:  maxQTime,
+	}
+}
+
+func (c *SearchFreshnessChecker) Name() string { return c.name }
+
+func (c *SearchFreshnessChecker) Run(ctx context.Context) (Result, error) {
+	rb := newResultBuilder()
+
+	q := solr.Query{
+		Params: solr.URLParamMap{"q": []string{c.query}},
+		Rows:   1,
+		Sort:   fmt.Sprintf("%s+desc", c.sortkey),
+	}
+
+	res, err := selectWithFailover(ctx, c.client, c.endpoints, c.core, c.username, c.password, &q)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to perform search query: %w", err)
+	}
+
+	results := res.Results
+
+	if res.Status != 0 {
+		rb.addResultf(nagiosplugin.CRITICAL, "Search failed: Invalid Solr response status.")
+	}
+	if results.Len() == 0 {
+		rb.addResultf(nagiosplugin.CRITICAL, "Search returned zero documents.")
+	}
+	if results.NumFound < c.minHits {
+		rb.addResultf(nagiosplugin.WARNING, "Number of documents hits is lower than expected")
+	}
+	if res.QTime > c.maxQTime {
+		rb.addResultf(nagiosplugin.CRITICAL, "Response too slow: %d ms", res.QTime)
+	}
+
+	if results.Len() > 0 {
+		// check the date of the document returned
+		// type-assert the result date field to a string
+		sortkeydate, ok := results.Get(0).Field(c.sortkey).(string)
+		if !ok {
+			return Result{}, fmt.Errorf("cannot parse date field %q specified in sortkey", c.sortkey)
+		}
+		lastupdate, err := time.Parse("2006-01-02T15:04:05Z", sortkeydate)
+		if err != nil {
+			return Result{}, fmt.Errorf("can't parse document's lastupdate field: %w", err)
+		}
+		rb.addPerfDatum("age", "s", time.Since(lastupdate).Seconds())
+
+		// check if last document's date is too old
+		if lastupdate.Before(time.Now().Add(-30 * time.Minute)) {
+			rb.addResultf(nagiosplugin.CRITICAL, "Collection update issue: Last document is too old (%s)", lastupdate)
+		}
+	}
+
+	rb.addPerfDatum("qtime", "ms", float64(res.QTime))
+	rb.addPerfDatum("documents", "c", float64(results.NumFound))
+	rb.addResultf(nagiosplugin.OK, "Search processed in %dms, %d documents found", res.QTime, results.NumFound)
+
+	return rb.result(), nil
+}
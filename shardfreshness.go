@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/olorin/nagiosplugin"
+	"github.com/rtt/Go-Solr"
+)
+
+// ShardFreshnessChecker looks up every shard of a collection (via
+// CLUSTERSTATUS) and queries an actual replica of each one directly with
+// distrib=false, so a single stuck shard can't be hidden behind a global
+// top-N search. It reports the OLDEST of the per-shard newest-document
+// ages.
+//
+// Each shard's query is sent straight to one of its own replicas (the
+// leader, if live) rather than through -servers/-host failover: with
+// distrib=false, a "shards" query param is ignored, so sending the
+// query to a random endpoint would silently read whatever shard that
+// node happens to host instead of the one being checked.
+//
+// An equivalent, more exhaustive approach is to page every shard with
+// Solr's cursorMark (sort=<sortkey>+desc,id+asc) until one hit per shard
+// is seen; this checker takes the simpler single distrib=false query per
+// shard since rows=1 already reflects each shard's own newest document.
+type ShardFreshnessChecker struct {
+	name      string
+	client    *http.Client
+	endpoints []string
+
+	username, password string
+	collection         string
+	query, sortkey     string
+	maxAge             int // seconds
+}
+
+// NewShardFreshnessChecker returns a ShardFreshnessChecker for
+// collection. maxAge is in seconds.
+func NewShardFreshnessChecker(name string, client *http.Client, endpoints []string, username, password, collection, query, sortkey string, maxAge int) *ShardFreshnessChecker {
+	return &ShardFreshnessChecker{
+		name:       name,
+		client:     client,
+		endpoints:  endpoints,
+		username:   username,
+		password:   password,
+		collection: collection,
+		query:      query,
+		sortkey:    sortkey,
+		maxAge:     maxAge,
+	}
+}
+
+func (c *ShardFreshnessChecker) Name() string { return c.name }
+
+// Collection implements collectionNamer.
+func (c *ShardFreshnessChecker) Collection() string { return c.collection }
+
+func (c *ShardFreshnessChecker) Run(ctx context.Context) (Result, error) {
+	rb := newResultBuilder()
+
+	path := fmt.Sprintf("/solr/admin/collections?action=CLUSTERSTATUS&collection=%s&wt=json", c.collection)
+
+	var status clusterStatusResponse
+	if err := getJSONWithFailover(ctx, c.client, c.endpoints, path, c.username, c.password, &status); err != nil {
+		return Result{}, fmt.Errorf("unable to fetch CLUSTERSTATUS: %w", err)
+	}
+
+	coll, ok := status.Cluster.Collections[c.collection]
+	if !ok {
+		return Result{}, fmt.Errorf("collection %q not found in CLUSTERSTATUS response", c.collection)
+	}
+	if len(coll.Shards) == 0 {
+		return Result{}, fmt.Errorf("collection %q has no shards", c.collection)
+	}
+
+	var oldestAge float64
+	var oldestShard string
+
+	for shardName, shard := range coll.Shards {
+		var baseURL, replicaCore string
+		for _, replica := range shard.Replicas {
+			if replica.State != "active" {
+				continue
+			}
+			baseURL, replicaCore = replica.BaseURL, replica.Core
+			if replica.Leader == "true" {
+				break
+			}
+		}
+		if baseURL == "" {
+			rb.addResultf(nagiosplugin.CRITICAL, "Shard %q has no active replica to query", shardName)
+			continue
+		}
+
+		q := solr.Query{
+			Params: solr.URLParamMap{
+				"q":       []string{c.query},
+				"distrib": []string{"false"},
+			},
+			Rows: 1,
+			Sort: fmt.Sprintf("%s+desc", c.sortkey),
+		}
+
+		endpoint := strings.TrimSuffix(baseURL, "/solr")
+		res, err := selectFromEndpoint(ctx, c.client, endpoint, replicaCore, c.username, c.password, &q)
+		if err != nil {
+			return Result{}, fmt.Errorf("shard %q: search query failed: %w", shardName, err)
+		}
+
+		if res.Results.Len() == 0 {
+			rb.addResultf(nagiosplugin.CRITICAL, "Shard %q returned zero documents", shardName)
+			continue
+		}
+
+		sortkeydate, ok := res.Results.Get(0).Field(c.sortkey).(string)
+		if !ok {
+			return Result{}, fmt.Errorf("shard %q: cannot parse date field %q specified in sortkey", shardName, c.sortkey)
+		}
+		lastupdate, err := time.Parse("2006-01-02T15:04:05Z", sortkeydate)
+		if err != nil {
+			return Result{}, fmt.Errorf("shard %q: can't parse document's lastupdate field: %w", shardName, err)
+		}
+
+		age := time.Since(lastupdate).Seconds()
+		rb.addPerfDatum("freshness_"+shardName, "s", age)
+
+		if age > oldestAge {
+			oldestAge = age
+			oldestShard = shardName
+		}
+		if int(age) > c.maxAge {
+			rb.addResultf(nagiosplugin.CRITICAL, "Shard %q's newest document is %.0fs old, exceeds -maxage %ds", shardName, age, c.maxAge)
+		}
+	}
+
+	rb.addResultf(nagiosplugin.OK, "Oldest shard freshness is %.0fs (shard %q)", oldestAge, oldestShard)
+
+	return rb.result(), nil
+}